@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Commit message styles supported by generateCommitMessage.
+const (
+	StyleFreeform     = "freeform"
+	StyleConventional = "conventional"
+)
+
+// conventionalTypes lists the commit types recognized in Conventional Commits mode.
+var conventionalTypes = []string{
+	"feat", "fix", "docs", "refactor", "perf", "test", "chore", "build", "ci",
+}
+
+// CommitMessage defines the structure for the LLM's generated commit message.
+// Title/Description are always populated; Type, Scope, Breaking and Footers
+// are only used when the configured style is StyleConventional.
+type CommitMessage struct {
+	Type        string   `json:"type,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	Breaking    bool     `json:"breaking,omitempty"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Footers     []string `json:"footers,omitempty"`
+}
+
+// Subject assembles the final commit subject line according to style. In
+// freeform mode it's just the title; in conventional mode it's rendered as
+// "type(scope)!: title".
+func (c *CommitMessage) Subject(style string) string {
+	if style != StyleConventional || c.Type == "" {
+		return c.Title
+	}
+
+	var b strings.Builder
+	b.WriteString(c.Type)
+	if c.Scope != "" {
+		b.WriteString("(")
+		b.WriteString(c.Scope)
+		b.WriteString(")")
+	}
+	if c.Breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": ")
+	b.WriteString(c.Title)
+	return b.String()
+}
+
+// Body assembles the commit body: the description followed by any footers,
+// including a BREAKING CHANGE footer when none was already supplied.
+func (c *CommitMessage) Body(style string) string {
+	if style != StyleConventional {
+		return c.Description
+	}
+
+	footers := c.Footers
+	if c.Breaking && !hasBreakingFooter(footers) {
+		footers = append([]string{"BREAKING CHANGE: " + c.Title}, footers...)
+	}
+
+	if len(footers) == 0 {
+		return c.Description
+	}
+	return strings.TrimRight(c.Description, "\n") + "\n\n" + strings.Join(footers, "\n")
+}
+
+func hasBreakingFooter(footers []string) bool {
+	for _, f := range footers {
+		if strings.HasPrefix(f, "BREAKING CHANGE:") {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidConventionalType reports whether t is one of the recognized
+// Conventional Commits types.
+func isValidConventionalType(t string) bool {
+	for _, valid := range conventionalTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+var diffFileHeaderRe = regexp.MustCompile(`(?m)^diff --git a/(\S+) b/(\S+)`)
+
+// inferScope derives a Conventional Commits scope from the paths touched in
+// diff by taking the shallowest common directory component. It returns an
+// empty string when no files are found or the paths don't share a directory.
+func inferScope(diff string) string {
+	matches := diffFileHeaderRe.FindAllStringSubmatch(diff, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	var scope string
+	for i, m := range matches {
+		dir := filepath.Dir(m[1])
+		if dir == "." {
+			dir = filepath.Base(m[1])
+		} else {
+			dir = strings.Split(dir, string(filepath.Separator))[0]
+		}
+
+		if i == 0 {
+			scope = dir
+			continue
+		}
+		if dir != scope {
+			return ""
+		}
+	}
+	return scope
+}
+
+var removedExportedSymbolRe = regexp.MustCompile(`(?m)^-(func|type|const|var) ([A-Z]\w*)`)
+
+// detectBreakingChange heuristically reports whether diff removes an
+// exported Go symbol (func/type/const/var), which is treated as a signal of
+// a breaking API change.
+func detectBreakingChange(diff string) bool {
+	return removedExportedSymbolRe.MatchString(diff)
+}
+
+// conventionalSchema returns the JSON schema fragment describing the extra
+// fields required in conventional mode, embedded into the prompt and the
+// request's response_format schema.
+func conventionalSchema(typeDesc, scopeDesc, breakingDesc, footersDesc string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": map[string]interface{}{
+			"type":        "string",
+			"description": typeDesc,
+			"enum":        conventionalTypes,
+		},
+		// scope and footers are optional in practice, but listed as nullable
+		// rather than omitted so they can still appear in `required` — strict
+		// structured-output modes (e.g. OpenAI's) require every property to be
+		// required, with "no value" expressed as null instead of absence.
+		"scope": map[string]interface{}{
+			"type":        []string{"string", "null"},
+			"description": scopeDesc,
+		},
+		"breaking": map[string]interface{}{
+			"type":        "boolean",
+			"description": breakingDesc,
+		},
+		"footers": map[string]interface{}{
+			"type":        []string{"array", "null"},
+			"description": footersDesc,
+			"items":       map[string]interface{}{"type": "string"},
+		},
+	}
+}
+
+// conventionalHint returns a short instruction snippet inserted into the
+// prompt so the LLM knows the inferred scope/breaking signal up front.
+func conventionalHint(inferredScope string, likelyBreaking bool) string {
+	return fmt.Sprintf("Inferred scope from touched paths: %q. Heuristic breaking-change detector: %v. Use these as a strong hint, but override them if the diff clearly says otherwise.", inferredScope, likelyBreaking)
+}
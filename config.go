@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Supported provider names for the "provider" config key / AUTO_COMMIT_PROVIDER env var.
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderOpenAI     = "openai"
+	ProviderAnthropic  = "anthropic"
+	ProviderGemini     = "gemini"
+	ProviderOllama     = "ollama"
+	ProviderCompatible = "compatible"
+)
+
+// Config holds the resolved LLM backend configuration: which provider to
+// talk to, which model, and how to authenticate/reach it, plus the default
+// commit message style used by entry points (like the git hook) that don't
+// take a --style flag.
+type Config struct {
+	Provider string
+	Model    string
+	BaseURL  string
+	APIKey   string
+	Style    string
+
+	// GeneratedGlobs adds extra path globs (on top of the built-in
+	// vendor/node_modules/etc. defaults) whose diffs are replaced with a
+	// placeholder before being sent to the LLM. Populated by repeated
+	// "generated_glob: <glob>" lines in the config file.
+	GeneratedGlobs []string
+	// RedactPatterns adds extra secret matchers as "name=regexp" pairs,
+	// populated by repeated "redact_pattern: <name>=<regexp>" lines.
+	RedactPatterns []string
+}
+
+// defaultConfig preserves the tool's original behavior when nothing else is configured.
+func defaultConfig() *Config {
+	return &Config{
+		Provider: ProviderOpenRouter,
+		Model:    llmModel,
+		Style:    StyleFreeform,
+	}
+}
+
+// LoadConfig resolves the provider configuration from, in increasing order
+// of precedence: ~/.auto_commit.yaml, then environment variables. Flags (if
+// any are added later) should be applied by the caller on top of the
+// returned Config.
+func LoadConfig() (*Config, error) {
+	cfg := defaultConfig()
+
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if fileCfg, err := readConfigFile(filepath.Join(home, ".auto_commit.yaml")); err != nil {
+			return nil, err
+		} else if fileCfg != nil {
+			mergeConfig(cfg, fileCfg)
+		}
+	}
+
+	mergeConfig(cfg, configFromEnv())
+
+	if cfg.APIKey == "" {
+		cfg.APIKey = apiKeyEnvVarForProvider(cfg.Provider)
+	}
+
+	return cfg, nil
+}
+
+// mergeConfig overwrites fields of dst with any non-empty fields of src.
+func mergeConfig(dst *Config, src *Config) {
+	if src == nil {
+		return
+	}
+	if src.Provider != "" {
+		dst.Provider = src.Provider
+	}
+	if src.Model != "" {
+		dst.Model = src.Model
+	}
+	if src.BaseURL != "" {
+		dst.BaseURL = src.BaseURL
+	}
+	if src.APIKey != "" {
+		dst.APIKey = src.APIKey
+	}
+	if src.Style != "" {
+		dst.Style = src.Style
+	}
+	dst.GeneratedGlobs = append(dst.GeneratedGlobs, src.GeneratedGlobs...)
+	dst.RedactPatterns = append(dst.RedactPatterns, src.RedactPatterns...)
+}
+
+func configFromEnv() *Config {
+	return &Config{
+		Provider: os.Getenv("AUTO_COMMIT_PROVIDER"),
+		Model:    os.Getenv("AUTO_COMMIT_MODEL"),
+		BaseURL:  os.Getenv("AUTO_COMMIT_BASE_URL"),
+		APIKey:   os.Getenv("AUTO_COMMIT_API_KEY"),
+		Style:    os.Getenv("AUTO_COMMIT_STYLE"),
+	}
+}
+
+// apiKeyEnvVarForProvider falls back to the provider's conventional env var
+// (e.g. OPENROUTER_API_KEY) when AUTO_COMMIT_API_KEY isn't set.
+func apiKeyEnvVarForProvider(provider string) string {
+	switch provider {
+	case ProviderOpenRouter:
+		return os.Getenv("OPENROUTER_API_KEY")
+	case ProviderOpenAI:
+		return os.Getenv("OPENAI_API_KEY")
+	case ProviderAnthropic:
+		return os.Getenv("ANTHROPIC_API_KEY")
+	case ProviderGemini:
+		return os.Getenv("GOOGLE_API_KEY")
+	default:
+		return ""
+	}
+}
+
+// readConfigFile parses a minimal "key: value" subset of YAML — one
+// scalar assignment per line, '#' comments, no nesting — which is all
+// ~/.auto_commit.yaml needs. Returns (nil, nil) if the file doesn't exist.
+func readConfigFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	cfg := &Config{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		switch key {
+		case "provider":
+			cfg.Provider = value
+		case "model":
+			cfg.Model = value
+		case "base_url":
+			cfg.BaseURL = value
+		case "api_key":
+			cfg.APIKey = value
+		case "style":
+			cfg.Style = value
+		case "generated_glob":
+			cfg.GeneratedGlobs = append(cfg.GeneratedGlobs, value)
+		case "redact_pattern":
+			cfg.RedactPatterns = append(cfg.RedactPatterns, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// estimateTokens gives a rough token count for s using the common
+// chars-per-token-4 approximation. It's only used to decide whether a diff
+// needs chunking, not for billing, so it doesn't need to be exact.
+func estimateTokens(s string) int {
+	return len(s) / 4
+}
+
+// modelContextWindows lists the context window (in tokens) of models we
+// know about. Unlisted models fall back to defaultContextWindow.
+var modelContextWindows = map[string]int{
+	"tngtech/deepseek-r1t2-chimera:free": 32000,
+	"gpt-4o":                             128000,
+	"gpt-4o-mini":                        128000,
+	"claude-3-5-sonnet-20241022":         200000,
+	"claude-3-5-haiku-20241022":          200000,
+	"gemini-1.5-pro":                     1000000,
+	"gemini-1.5-flash":                   1000000,
+	"llama3":                             8192,
+}
+
+const defaultContextWindow = 8192
+
+// contextWindowForModel returns the known context window for model, or
+// defaultContextWindow if it isn't in modelContextWindows.
+func contextWindowForModel(model string) int {
+	if window, ok := modelContextWindows[model]; ok {
+		return window
+	}
+	return defaultContextWindow
+}
+
+// FileChange is one file's worth of a unified diff, split out of a larger
+// `git diff` so it can be summarized independently.
+type FileChange struct {
+	Path       string
+	ChangeKind string // "added", "deleted", "renamed", "modified"
+	Diff       string
+}
+
+var diffGitHeaderRe = regexp.MustCompile(`(?m)^diff --git a/(\S+) b/(\S+)`)
+
+// splitDiffByFile splits a unified diff produced by `git diff` into one
+// FileChange per file.
+func splitDiffByFile(diff string) []FileChange {
+	headers := diffGitHeaderRe.FindAllStringSubmatchIndex(diff, -1)
+	if len(headers) == 0 {
+		return nil
+	}
+
+	changes := make([]FileChange, 0, len(headers))
+	for i, h := range headers {
+		start := h[0]
+		end := len(diff)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		section := diff[start:end]
+
+		path := diff[h[2]:h[3]]
+		if newPath := diff[h[4]:h[5]]; newPath != "" {
+			path = newPath
+		}
+
+		changes = append(changes, FileChange{
+			Path:       path,
+			ChangeKind: classifyChangeKind(section),
+			Diff:       section,
+		})
+	}
+	return changes
+}
+
+func classifyChangeKind(section string) string {
+	switch {
+	case strings.Contains(section, "\nnew file mode"):
+		return "added"
+	case strings.Contains(section, "\ndeleted file mode"):
+		return "deleted"
+	case strings.Contains(section, "\nrename from "):
+		return "renamed"
+	default:
+		return "modified"
+	}
+}
+
+var hunkHeaderRe = regexp.MustCompile(`(?m)^@@ `)
+
+// chunkFileDiff splits a single file's diff into pieces that each stay under
+// maxTokens, by grouping whole hunks together. The file header (everything
+// before the first hunk) is repeated at the top of every piece so the LLM
+// has enough context to summarize it in isolation.
+func chunkFileDiff(fileDiff string, maxTokens int) []string {
+	if estimateTokens(fileDiff) <= maxTokens {
+		return []string{fileDiff}
+	}
+
+	hunkStarts := hunkHeaderRe.FindAllStringIndex(fileDiff, -1)
+	if len(hunkStarts) == 0 {
+		return []string{fileDiff}
+	}
+
+	header := fileDiff[:hunkStarts[0][0]]
+	var pieces []string
+	var current strings.Builder
+	current.WriteString(header)
+
+	flush := func() {
+		if current.Len() > len(header) {
+			pieces = append(pieces, current.String())
+		}
+		current.Reset()
+		current.WriteString(header)
+	}
+
+	for i, start := range hunkStarts {
+		end := len(fileDiff)
+		if i+1 < len(hunkStarts) {
+			end = hunkStarts[i+1][0]
+		}
+		hunk := fileDiff[start[0]:end]
+
+		if estimateTokens(current.String())+estimateTokens(hunk) > maxTokens && current.Len() > len(header) {
+			flush()
+		}
+		current.WriteString(hunk)
+	}
+	flush()
+
+	return pieces
+}
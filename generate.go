@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// generateCommit runs the shared sanitize-then-prepare-then-generate
+// pipeline used by both the standalone CLI and the prepare-commit-msg hook
+// entry point.
+func generateCommit(ctx context.Context, provider Provider, cfg *Config, style, language, extraInstruction, rawDiff, statusShort string) (*CommitMessage, error) {
+	sanitized, report, err := NewDiffSanitizer(cfg).Sanitize(rawDiff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sanitize diff: %w", err)
+	}
+	for _, line := range report {
+		log.Printf("Redacted from diff: %s\n", line)
+	}
+
+	diffOutput, err := prepareDiff(ctx, provider, sanitized, statusShort, cfg.Model)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Generate(ctx, diffOutput, GenerateOptions{Style: style, Language: language, ExtraInstruction: extraInstruction})
+}
+
+// resolveProvider loads the config and constructs the matching Provider,
+// erroring out if no API key is available (Ollama is the only provider that
+// doesn't need one).
+func resolveProvider() (*Config, Provider, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.APIKey == "" && cfg.Provider != ProviderOllama {
+		return nil, nil, fmt.Errorf("no API key configured for provider %q; set it in ~/.auto_commit.yaml or the matching env var", cfg.Provider)
+	}
+
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, provider, nil
+}
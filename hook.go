@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies a prepare-commit-msg hook file as one we installed,
+// so install-hook can tell it apart from a user's own hook.
+const hookMarker = "# managed by: auto_commit install-hook"
+
+// skipHookSources lists the prepare-commit-msg $2 values for which we leave
+// the message alone: the user already supplied one with -m/-F ("message"),
+// it's an existing commit being reused ("commit", e.g. via -c/--amend), or
+// it's a merge/squash message git already populated.
+var skipHookSources = map[string]bool{
+	"message": true,
+	"commit":  true,
+	"merge":   true,
+	"squash":  true,
+}
+
+// installHook writes a prepare-commit-msg hook into the repository's git
+// hooks directory (respecting core.hooksPath) that shells out to this same
+// binary's "run-hook" subcommand.
+func installHook() error {
+	hooksDir, err := gitHooksDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	hookPath := filepath.Join(hooksDir, "prepare-commit-msg")
+	if existing, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("a prepare-commit-msg hook already exists at %s; remove it or merge it with auto_commit's hook manually", hookPath)
+	}
+
+	binPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to resolve path to auto_commit binary: %w", err)
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\n%s\nexec %q run-hook \"$1\" \"$2\" \"$3\"\n", hookMarker, binPath)
+	if err := os.WriteFile(hookPath, []byte(script), 0o755); err != nil {
+		return fmt.Errorf("failed to write hook file: %w", err)
+	}
+
+	fmt.Printf("Installed prepare-commit-msg hook at %s\n", hookPath)
+	return nil
+}
+
+// gitHooksDir resolves the repository's hooks directory, honoring
+// core.hooksPath if the user has configured one.
+func gitHooksDir() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git hooks directory: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runHook is the prepare-commit-msg entry point: args are the hook's
+// positional parameters ($1 the commit message file, $2 the message
+// source, $3 the commit SHA when amending). It generates a commit message
+// from the staged diff and prepends it to the message file, leaving any
+// existing content (e.g. git's comment scaffolding) intact below it.
+func runHook(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("run-hook requires the commit message file as its first argument")
+	}
+	msgFile := args[0]
+	source := ""
+	if len(args) > 1 {
+		source = args[1]
+	}
+
+	if skipHookSources[source] {
+		return nil
+	}
+
+	statusShort, err := getGitStatusShort()
+	if err != nil {
+		return err
+	}
+	rawDiff, err := getGitDiffCached()
+	if err != nil {
+		return err
+	}
+	if rawDiff == "" {
+		return nil
+	}
+
+	cfg, provider, err := resolveProvider()
+	if err != nil {
+		return err
+	}
+
+	style := cfg.Style
+	if style == "" {
+		style = StyleFreeform
+	}
+
+	commitMsg, err := generateCommit(context.Background(), provider, cfg, style, "English", "", rawDiff, statusShort)
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(msgFile)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message file: %w", err)
+	}
+
+	generated := commitMsg.Subject(style) + "\n\n" + commitMsg.Body(style) + "\n"
+	return os.WriteFile(msgFile, append([]byte(generated), existing...), 0o644)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// promptSpec holds everything needed to ask an LLM for a commit message: the
+// natural-language instruction and the JSON schema the response must match.
+type promptSpec struct {
+	Instruction string
+	Schema      map[string]interface{}
+}
+
+// buildPromptSpec assembles the instruction text and JSON schema for diff,
+// honoring language, style and an optional extra instruction (used when
+// regenerating). Providers that support structured outputs pass Schema
+// straight through as response_format; providers that don't embed it in the
+// prompt text via renderPrompt.
+func buildPromptSpec(diff, language, style, extraInstruction string) promptSpec {
+	var instruction, titleDesc, descriptionDesc string
+	var typeDesc, scopeDesc, breakingDesc, footersDesc string
+
+	if language == "Spanish" {
+		instruction = "Analiza el siguiente git diff y genera un título de commit conciso (máx. 70 caracteres) y una descripción detallada del commit. Responde en formato JSON de acuerdo con el esquema:"
+		titleDesc = "Título conciso del mensaje de commit"
+		descriptionDesc = "Descripción detallada del mensaje de commit"
+		typeDesc = "Tipo de Conventional Commits (feat, fix, docs, refactor, perf, test, chore, build, ci)"
+		scopeDesc = "Alcance opcional del commit, p. ej. el módulo afectado"
+		breakingDesc = "Verdadero si el cambio rompe la compatibilidad de la API pública"
+		footersDesc = "Líneas de pie de página adicionales, p. ej. 'BREAKING CHANGE: ...' o 'Refs: ...'"
+	} else {
+		instruction = "Analyze the following git diff and generate a concise commit title (max 70 chars) and a detailed commit description. Respond in JSON format according to the schema:"
+		titleDesc = "Concise commit message title"
+		descriptionDesc = "Detailed commit message description"
+		typeDesc = "Conventional Commits type (feat, fix, docs, refactor, perf, test, chore, build, ci)"
+		scopeDesc = "Optional commit scope, e.g. the affected module"
+		breakingDesc = "True if the change breaks the public API"
+		footersDesc = "Additional footer lines, e.g. 'BREAKING CHANGE: ...' or 'Refs: ...'"
+	}
+
+	properties := map[string]interface{}{
+		"title": map[string]interface{}{
+			"type":        "string",
+			"description": titleDesc,
+		},
+		"description": map[string]interface{}{
+			"type":        "string",
+			"description": descriptionDesc,
+		},
+	}
+	required := []string{"title", "description"}
+
+	if style == StyleConventional {
+		inferredScope := inferScope(diff)
+		likelyBreaking := detectBreakingChange(diff)
+		instruction = instruction + " " + conventionalHint(inferredScope, likelyBreaking)
+
+		for k, v := range conventionalSchema(typeDesc, scopeDesc, breakingDesc, footersDesc) {
+			properties[k] = v
+		}
+		// All conventional-mode properties must be required for strict
+		// structured-output providers to accept the schema; scope/footers are
+		// nullable above to still allow "no value".
+		required = append(required, "type", "scope", "breaking", "footers")
+	}
+
+	if extraInstruction != "" {
+		instruction = instruction + " Additional instruction from the user: " + extraInstruction
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+
+	return promptSpec{Instruction: instruction, Schema: schema}
+}
+
+// renderPrompt renders spec and diff into a single prompt string, for
+// providers that don't support a structured response_format and must be
+// told the schema as plain text. retryNote, when non-empty, is prepended to
+// ask the model to fix a previously invalid response.
+func (s promptSpec) renderPrompt(diff, retryNote string) string {
+	schemaBytes, err := json.MarshalIndent(s.Schema, "", "  ")
+	if err != nil {
+		// Schema is built from static maps above; this should never happen.
+		schemaBytes = []byte("{}")
+	}
+
+	instruction := s.Instruction
+	if retryNote != "" {
+		instruction = retryNote + " " + instruction
+	}
+
+	return fmt.Sprintf("%s\n\n```json\n%s\n```\n\nGit Diff:\n```diff\n%s\n```", instruction, schemaBytes, diff)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GenerateOptions carries the per-call parameters a Provider needs to
+// produce a CommitMessage, on top of whatever base configuration
+// (model, API key, base URL) the provider was constructed with.
+type GenerateOptions struct {
+	Style            string
+	Language         string
+	ExtraInstruction string
+}
+
+// Provider generates a CommitMessage from a git diff. Each supported LLM
+// backend (OpenRouter, OpenAI, Anthropic, Gemini, Ollama, a generic
+// OpenAI-compatible endpoint) implements this with its own request/response
+// adapter.
+type Provider interface {
+	Generate(ctx context.Context, diff string, opts GenerateOptions) (*CommitMessage, error)
+}
+
+// NewProvider constructs the Provider named by cfg.Provider.
+func NewProvider(cfg *Config) (Provider, error) {
+	switch cfg.Provider {
+	case ProviderOpenRouter:
+		return &OpenRouterProvider{APIKey: cfg.APIKey, Model: cfg.Model, BaseURL: nonEmpty(cfg.BaseURL, "https://openrouter.ai/api/v1/chat/completions")}, nil
+	case ProviderOpenAI:
+		return &OpenAIProvider{APIKey: cfg.APIKey, Model: cfg.Model, BaseURL: nonEmpty(cfg.BaseURL, "https://api.openai.com/v1/chat/completions")}, nil
+	case ProviderAnthropic:
+		return &AnthropicProvider{APIKey: cfg.APIKey, Model: cfg.Model, BaseURL: nonEmpty(cfg.BaseURL, "https://api.anthropic.com/v1/messages")}, nil
+	case ProviderGemini:
+		return &GeminiProvider{APIKey: cfg.APIKey, Model: cfg.Model, BaseURL: nonEmpty(cfg.BaseURL, "https://generativelanguage.googleapis.com/v1beta/models")}, nil
+	case ProviderOllama:
+		return &OllamaProvider{Model: cfg.Model, BaseURL: nonEmpty(cfg.BaseURL, "http://localhost:11434/api/chat")}, nil
+	case ProviderCompatible:
+		return &CompatibleProvider{APIKey: cfg.APIKey, Model: cfg.Model, BaseURL: cfg.BaseURL}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+}
+
+func nonEmpty(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+var jsonFenceRe = regexp.MustCompile("(?s)```(?:json)?\\s*(.*?)\\s*```")
+
+// extractJSON strips a surrounding ```json ... ``` fence if present, since
+// some providers ignore instructions to respond with raw JSON only.
+func extractJSON(content string) string {
+	if m := jsonFenceRe.FindStringSubmatch(content); m != nil {
+		return m[1]
+	}
+	return strings.TrimSpace(content)
+}
+
+// parseCommitMessage unmarshals content into a CommitMessage and validates
+// that the fields required by style are present.
+func parseCommitMessage(content, style string) (*CommitMessage, error) {
+	var msg CommitMessage
+	if err := json.Unmarshal([]byte(extractJSON(content)), &msg); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if strings.TrimSpace(msg.Title) == "" {
+		return nil, fmt.Errorf("missing required field %q", "title")
+	}
+	if strings.TrimSpace(msg.Description) == "" {
+		return nil, fmt.Errorf("missing required field %q", "description")
+	}
+	if style == StyleConventional {
+		if !isValidConventionalType(msg.Type) {
+			return nil, fmt.Errorf("missing or invalid required field %q", "type")
+		}
+	}
+
+	return &msg, nil
+}
+
+// generateWithJSONRetry calls call (which performs the actual HTTP
+// round-trip and returns the raw model content) and validates the result
+// against style's required fields, retrying once with a note asking the
+// model to fix its output if parsing/validation fails. Used by providers
+// that can't rely on a strict structured-output mode.
+func generateWithJSONRetry(opts GenerateOptions, call func(retryNote string) (string, error)) (*CommitMessage, error) {
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		retryNote := ""
+		if attempt > 0 {
+			retryNote = fmt.Sprintf("Your previous response was invalid (%v). Respond with ONLY a single JSON object matching the schema below, no prose and no markdown fences.", lastErr)
+		}
+
+		content, err := call(retryNote)
+		if err != nil {
+			return nil, err
+		}
+
+		msg, err := parseCommitMessage(content, opts.Style)
+		if err == nil {
+			return msg, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("LLM response invalid after retry: %w", lastErr)
+}
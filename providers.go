@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// chatMessage is the common OpenAI-style chat message shape shared by
+// OpenRouter, OpenAI and generic OpenAI-compatible endpoints.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatResponse is the subset of an OpenAI-style chat completion response we need.
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+func (r chatResponse) content() (string, error) {
+	if len(r.Choices) == 0 || r.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("LLM response contained no choices or empty content")
+	}
+	return r.Choices[0].Message.Content, nil
+}
+
+// postJSON POSTs body (already JSON-encoded) to url with the given headers
+// and decodes the response into out, surfacing non-2xx bodies as errors.
+func postJSON(ctx context.Context, url string, headers map[string]string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode LLM response: %w", err)
+	}
+	return nil
+}
+
+// OpenRouterProvider talks to OpenRouter's OpenAI-compatible chat completions
+// API, using response_format: json_schema for structured output.
+type OpenRouterProvider struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+func (p *OpenRouterProvider) Generate(ctx context.Context, diff string, opts GenerateOptions) (*CommitMessage, error) {
+	return generateStrictJSONSchema(ctx, p.BaseURL, p.Model, p.APIKey, diff, opts)
+}
+
+// OpenAIProvider talks to the OpenAI chat completions API, which also
+// supports response_format: json_schema.
+type OpenAIProvider struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+func (p *OpenAIProvider) Generate(ctx context.Context, diff string, opts GenerateOptions) (*CommitMessage, error) {
+	return generateStrictJSONSchema(ctx, p.BaseURL, p.Model, p.APIKey, diff, opts)
+}
+
+// CompatibleProvider targets a generic OpenAI-compatible endpoint (self-hosted
+// gateways, proxies, etc.) whose support for response_format: json_schema is
+// unknown, so it falls back to prompt-only JSON with validation and retry.
+type CompatibleProvider struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+func (p *CompatibleProvider) Generate(ctx context.Context, diff string, opts GenerateOptions) (*CommitMessage, error) {
+	spec := buildPromptSpec(diff, opts.Language, opts.Style, opts.ExtraInstruction)
+
+	return generateWithJSONRetry(opts, func(retryNote string) (string, error) {
+		reqBody := map[string]interface{}{
+			"model": p.Model,
+			"messages": []chatMessage{
+				{Role: "user", Content: spec.renderPrompt(diff, retryNote)},
+			},
+		}
+		bodyBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal LLM request: %w", err)
+		}
+
+		var res chatResponse
+		headers := map[string]string{}
+		if p.APIKey != "" {
+			headers["Authorization"] = "Bearer " + p.APIKey
+		}
+		if err := postJSON(ctx, p.BaseURL, headers, bodyBytes, &res); err != nil {
+			return "", err
+		}
+		return res.content()
+	})
+}
+
+// generateStrictJSONSchema is shared by providers (OpenRouter, OpenAI) whose
+// chat completions API supports response_format: json_schema.
+func generateStrictJSONSchema(ctx context.Context, baseURL, model, apiKey, diff string, opts GenerateOptions) (*CommitMessage, error) {
+	spec := buildPromptSpec(diff, opts.Language, opts.Style, opts.ExtraInstruction)
+
+	reqBody := map[string]interface{}{
+		"model": model,
+		"messages": []chatMessage{
+			{Role: "user", Content: spec.renderPrompt(diff, "")},
+		},
+		"response_format": map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "commit_message",
+				"strict": true,
+				"schema": spec.Schema,
+			},
+		},
+	}
+	bodyBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal LLM request: %w", err)
+	}
+
+	var res chatResponse
+	if err := postJSON(ctx, baseURL, map[string]string{"Authorization": "Bearer " + apiKey}, bodyBytes, &res); err != nil {
+		return nil, err
+	}
+
+	content, err := res.content()
+	if err != nil {
+		return nil, err
+	}
+	return parseCommitMessage(content, opts.Style)
+}
+
+// AnthropicProvider talks to the Anthropic Messages API, which has no
+// response_format: json_schema equivalent, so it relies on prompt-only JSON
+// with validation and retry.
+type AnthropicProvider struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+func (p *AnthropicProvider) Generate(ctx context.Context, diff string, opts GenerateOptions) (*CommitMessage, error) {
+	spec := buildPromptSpec(diff, opts.Language, opts.Style, opts.ExtraInstruction)
+
+	return generateWithJSONRetry(opts, func(retryNote string) (string, error) {
+		reqBody := map[string]interface{}{
+			"model":      p.Model,
+			"max_tokens": 1024,
+			"messages": []chatMessage{
+				{Role: "user", Content: spec.renderPrompt(diff, retryNote)},
+			},
+		}
+		bodyBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal LLM request: %w", err)
+		}
+
+		var res struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		}
+		headers := map[string]string{
+			"x-api-key":         p.APIKey,
+			"anthropic-version": "2023-06-01",
+		}
+		if err := postJSON(ctx, p.BaseURL, headers, bodyBytes, &res); err != nil {
+			return "", err
+		}
+		if len(res.Content) == 0 || res.Content[0].Text == "" {
+			return "", fmt.Errorf("LLM response contained no content")
+		}
+		return res.Content[0].Text, nil
+	})
+}
+
+// GeminiProvider talks to the Google Gemini generateContent API. Gemini's
+// response schema dialect differs enough from JSON Schema that we rely on
+// prompt-only JSON with validation and retry rather than translating it.
+type GeminiProvider struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+func (p *GeminiProvider) Generate(ctx context.Context, diff string, opts GenerateOptions) (*CommitMessage, error) {
+	spec := buildPromptSpec(diff, opts.Language, opts.Style, opts.ExtraInstruction)
+	url := fmt.Sprintf("%s/%s:generateContent?key=%s", p.BaseURL, p.Model, p.APIKey)
+
+	return generateWithJSONRetry(opts, func(retryNote string) (string, error) {
+		reqBody := map[string]interface{}{
+			"contents": []map[string]interface{}{
+				{
+					"role":  "user",
+					"parts": []map[string]string{{"text": spec.renderPrompt(diff, retryNote)}},
+				},
+			},
+			"generationConfig": map[string]interface{}{
+				"responseMimeType": "application/json",
+			},
+		}
+		bodyBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal LLM request: %w", err)
+		}
+
+		var res struct {
+			Candidates []struct {
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"candidates"`
+		}
+		if err := postJSON(ctx, url, nil, bodyBytes, &res); err != nil {
+			return "", err
+		}
+		if len(res.Candidates) == 0 || len(res.Candidates[0].Content.Parts) == 0 {
+			return "", fmt.Errorf("LLM response contained no candidates")
+		}
+		return res.Candidates[0].Content.Parts[0].Text, nil
+	})
+}
+
+// OllamaProvider talks to a local Ollama server's chat API. Ollama only
+// supports a coarse "format": "json" mode, not a full schema, so it relies
+// on prompt-only JSON with validation and retry.
+type OllamaProvider struct {
+	Model   string
+	BaseURL string
+}
+
+func (p *OllamaProvider) Generate(ctx context.Context, diff string, opts GenerateOptions) (*CommitMessage, error) {
+	spec := buildPromptSpec(diff, opts.Language, opts.Style, opts.ExtraInstruction)
+
+	return generateWithJSONRetry(opts, func(retryNote string) (string, error) {
+		reqBody := map[string]interface{}{
+			"model":  p.Model,
+			"stream": false,
+			"format": "json",
+			"messages": []chatMessage{
+				{Role: "user", Content: spec.renderPrompt(diff, retryNote)},
+			},
+		}
+		bodyBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal LLM request: %w", err)
+		}
+
+		var res struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		}
+		if err := postJSON(ctx, p.BaseURL, nil, bodyBytes, &res); err != nil {
+			return "", err
+		}
+		if res.Message.Content == "" {
+			return "", fmt.Errorf("LLM response contained no content")
+		}
+		return res.Message.Content, nil
+	})
+}
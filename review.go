@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// regenerateFunc re-runs commit message generation with an optional extra
+// instruction (e.g. "make it shorter").
+type regenerateFunc func(extraInstruction string) (*CommitMessage, error)
+
+// reviewLoop shows the generated commit message to the user and lets them
+// Accept, Edit, Regenerate or Abort before anything is committed. It returns
+// the (possibly edited/regenerated) message, or a nil message if the user
+// aborted.
+func reviewLoop(commitMsg *CommitMessage, style string, regenerate regenerateFunc) (*CommitMessage, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Println("\n--- Generated commit message ---")
+		fmt.Printf("Subject: %s\n\n%s\n", commitMsg.Subject(style), commitMsg.Body(style))
+		fmt.Println("---------------------------------")
+		fmt.Print("[A]ccept, [e]dit, [r]egenerate, [q]uit? [A/e/r/q] ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "", "a", "accept":
+			return commitMsg, nil
+		case "e", "edit":
+			edited, err := editCommitMessage(commitMsg, style)
+			if err != nil {
+				log.Printf("Edit failed: %v\n", err)
+				continue
+			}
+			commitMsg = edited
+		case "r", "regenerate":
+			fmt.Print("Extra instruction (optional, e.g. \"make it shorter\"): ")
+			extra, _ := reader.ReadString('\n')
+			regenerated, err := regenerate(strings.TrimSpace(extra))
+			if err != nil {
+				log.Printf("Regenerate failed: %v\n", err)
+				continue
+			}
+			commitMsg = regenerated
+		case "q", "quit", "abort":
+			return nil, nil
+		default:
+			fmt.Println("Please answer a, e, r or q.")
+		}
+	}
+}
+
+// editCommitMessage opens $EDITOR (falling back to vi) on a temp file
+// pre-filled with the subject and body, similar to `git commit -e`, and
+// parses the result back into a CommitMessage. The first line becomes the
+// title and the rest becomes the description; Type/Scope/Breaking/Footers
+// are cleared since the edited text is now authoritative and already
+// contains any rendered scope/breaking-marker/footer text.
+func editCommitMessage(commitMsg *CommitMessage, style string) (*CommitMessage, error) {
+	tmpFile, err := os.CreateTemp("", "auto_commit-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	content := commitMsg.Subject(style) + "\n\n" + commitMsg.Body(style) + "\n"
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	cmd := exec.Command(editor, tmpFile.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor exited with error: %w", err)
+	}
+
+	edited, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited file: %w", err)
+	}
+
+	lines := strings.SplitN(strings.TrimLeft(string(edited), "\n"), "\n", 2)
+	title := strings.TrimSpace(lines[0])
+	if title == "" {
+		return nil, fmt.Errorf("commit title cannot be empty")
+	}
+
+	description := ""
+	if len(lines) > 1 {
+		description = strings.TrimSpace(lines[1])
+	}
+
+	updated := *commitMsg
+	updated.Type = ""
+	updated.Scope = ""
+	updated.Breaking = false
+	updated.Title = title
+	updated.Description = description
+	updated.Footers = nil
+	return &updated, nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultGeneratedGlobs lists path globs whose diffs are replaced with a
+// one-line placeholder by default, since their contents are rarely useful
+// (or safe) to hand to an LLM.
+var defaultGeneratedGlobs = []string{
+	"vendor/**",
+	"node_modules/**",
+	"*.min.js",
+	"*.pb.go",
+}
+
+// RedactionMatcher finds and masks one kind of secret in a single diff line.
+// PathFilter, when non-nil, restricts the matcher to files whose path it
+// accepts; nil means the matcher runs against every file.
+type RedactionMatcher struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+	PathFilter  func(path string) bool
+}
+
+// appliesTo reports whether the matcher should run against path.
+func (m RedactionMatcher) appliesTo(path string) bool {
+	return m.PathFilter == nil || m.PathFilter(path)
+}
+
+// redact returns line with any match replaced, and whether a replacement happened.
+func (m RedactionMatcher) redact(line string) (string, bool) {
+	if !m.Pattern.MatchString(line) {
+		return line, false
+	}
+	return m.Pattern.ReplaceAllString(line, m.Replacement), true
+}
+
+// isEnvPath reports whether path looks like a dotenv file (.env, .env.local,
+// .env.production, secrets.env, ...).
+func isEnvPath(path string) bool {
+	base := filepath.Base(path)
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// defaultRedactionMatchers covers the common secret shapes worth scrubbing
+// from a diff before it leaves the machine: cloud/VCS API keys, JWTs,
+// private key blocks, and .env-style KEY=VALUE assignments.
+func defaultRedactionMatchers() []RedactionMatcher {
+	return []RedactionMatcher{
+		{Name: "aws-access-key", Pattern: regexp.MustCompile(`AKIA[0-9A-Z]{16}`), Replacement: "[REDACTED-AWS-KEY]"},
+		{Name: "github-token", Pattern: regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}`), Replacement: "[REDACTED-GITHUB-TOKEN]"},
+		{Name: "jwt", Pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), Replacement: "[REDACTED-JWT]"},
+		{Name: "private-key-block", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`), Replacement: "[REDACTED-PRIVATE-KEY]"},
+		{
+			Name:        "dotenv-assignment",
+			Pattern:     regexp.MustCompile(`^[+\-]?\s*[A-Z_][A-Z0-9_]*\s*=\s*\S+`),
+			Replacement: "[REDACTED-ENV-ASSIGNMENT]",
+			PathFilter:  isEnvPath,
+		},
+	}
+}
+
+// DiffSanitizer filters a git diff before it's sent to an LLM: it skips
+// binary/LFS files, replaces diffs under generated/vendored paths with a
+// placeholder, and scrubs lines matching Matchers.
+type DiffSanitizer struct {
+	GeneratedGlobs []string
+	Matchers       []RedactionMatcher
+}
+
+// NewDiffSanitizer builds a DiffSanitizer from the built-in defaults plus
+// any project-specific globs/patterns configured in cfg.
+func NewDiffSanitizer(cfg *Config) *DiffSanitizer {
+	s := &DiffSanitizer{
+		GeneratedGlobs: append([]string{}, defaultGeneratedGlobs...),
+		Matchers:       defaultRedactionMatchers(),
+	}
+	s.GeneratedGlobs = append(s.GeneratedGlobs, cfg.GeneratedGlobs...)
+
+	for _, raw := range cfg.RedactPatterns {
+		name, pattern, ok := strings.Cut(raw, "=")
+		if !ok {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		s.Matchers = append(s.Matchers, RedactionMatcher{Name: name, Pattern: re, Replacement: fmt.Sprintf("[REDACTED-%s]", strings.ToUpper(name))})
+	}
+
+	return s
+}
+
+// isGenerated reports whether path matches one of s.GeneratedGlobs.
+func (s *DiffSanitizer) isGenerated(path string) bool {
+	for _, glob := range s.GeneratedGlobs {
+		if ok, _ := filepath.Match(glob, path); ok {
+			return true
+		}
+		// filepath.Match doesn't support "**"; fall back to a directory-prefix
+		// check for globs like "vendor/**".
+		if prefix, isDirGlob := strings.CutSuffix(glob, "/**"); isDirGlob && strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Sanitize filters diff and returns the sanitized text plus a human-readable
+// report of what was skipped or redacted (logged before the diff is sent to
+// an LLM).
+func (s *DiffSanitizer) Sanitize(diff string) (string, []string, error) {
+	changes := splitDiffByFile(diff)
+	if len(changes) == 0 {
+		return diff, nil, nil
+	}
+
+	binaryOrLFS, err := binaryOrLFSPaths(changes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var out strings.Builder
+	var report []string
+
+	for _, fc := range changes {
+		switch {
+		case binaryOrLFS[fc.Path]:
+			out.WriteString(placeholderDiff(fc, "binary or LFS file, diff omitted"))
+			report = append(report, fmt.Sprintf("%s: skipped (binary/LFS)", fc.Path))
+		case s.isGenerated(fc.Path):
+			out.WriteString(placeholderDiff(fc, "generated/vendored file, diff omitted"))
+			report = append(report, fmt.Sprintf("%s: skipped (generated/vendored)", fc.Path))
+		default:
+			sanitized, counts := s.scrubSecrets(fc.Path, fc.Diff)
+			out.WriteString(sanitized)
+			for name, n := range counts {
+				report = append(report, fmt.Sprintf("%s: redacted %d %s match(es)", fc.Path, n, name))
+			}
+		}
+	}
+
+	return out.String(), report, nil
+}
+
+// placeholderDiff keeps the file header (so the LLM still knows the path
+// and change kind changed) but collapses the hunks into one summary line.
+func placeholderDiff(fc FileChange, reason string) string {
+	header := fc.Diff
+	if idx := hunkHeaderRe.FindStringIndex(fc.Diff); idx != nil {
+		header = fc.Diff[:idx[0]]
+	}
+	return header + fmt.Sprintf("@@ %s @@\n", reason)
+}
+
+// scrubSecrets runs every matcher that applies to path over each line of
+// diff, returning the scrubbed text and a count of redactions per matcher name.
+func (s *DiffSanitizer) scrubSecrets(path, diff string) (string, map[string]int) {
+	counts := map[string]int{}
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		for _, m := range s.Matchers {
+			if !m.appliesTo(path) {
+				continue
+			}
+			redacted, matched := m.redact(line)
+			if matched {
+				line = redacted
+				counts[m.Name]++
+			}
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n"), counts
+}
+
+// binaryOrLFSPaths batches a `git check-attr -a --stdin` call over every
+// changed path and returns the set of paths attributed as binary or
+// filter=lfs.
+func binaryOrLFSPaths(changes []FileChange) (map[string]bool, error) {
+	var stdin strings.Builder
+	for _, fc := range changes {
+		stdin.WriteString(fc.Path)
+		stdin.WriteString("\n")
+	}
+
+	cmd := exec.Command("git", "check-attr", "-a", "--stdin")
+	cmd.Stdin = strings.NewReader(stdin.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git check-attr: %w", err)
+	}
+
+	result := map[string]bool{}
+	for _, line := range strings.Split(string(out), "\n") {
+		// Format: "<path>: <attr>: <value>"
+		parts := strings.SplitN(line, ": ", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		path, attr, value := parts[0], parts[1], parts[2]
+		if attr == "binary" && value == "set" {
+			result[path] = true
+		}
+		if attr == "filter" && value == "lfs" {
+			result[path] = true
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// FileSummary is the map-step output for one file's worth of diff.
+type FileSummary struct {
+	Path       string `json:"path"`
+	ChangeKind string `json:"changeKind"`
+	Summary    string `json:"summary"`
+}
+
+// summaryCacheBudgetFraction is how much of a model's context window a
+// single diff may use before it gets chunked and summarized instead of
+// being sent as-is.
+const summaryCacheBudgetFraction = 2 // diff must be under window/2 tokens to skip chunking
+
+// needsChunking reports whether diff is large enough, relative to model's
+// context window, that it should go through the map/reduce summarization
+// path instead of being sent to the LLM directly.
+func needsChunking(diff, model string) bool {
+	return estimateTokens(diff) > contextWindowForModel(model)/summaryCacheBudgetFraction
+}
+
+// prepareDiff returns the text to hand to the final commit-message
+// generation call: `git status --short` plus the original diff, if it's
+// small enough; or `git status --short` plus one summary per changed file,
+// if not.
+func prepareDiff(ctx context.Context, provider Provider, diff, statusShort, model string) (string, error) {
+	if !needsChunking(diff, model) {
+		return renderDiffWithStatus(statusShort, diff), nil
+	}
+
+	changes := splitDiffByFile(diff)
+	if len(changes) == 0 {
+		return renderDiffWithStatus(statusShort, diff), nil
+	}
+
+	cache, err := loadSummaryCache()
+	if err != nil {
+		return "", err
+	}
+
+	var summaries []FileSummary
+	budget := contextWindowForModel(model) / 4
+
+	for _, fc := range changes {
+		key := summaryCacheKey(fc)
+
+		if cached, ok := cache[key]; ok {
+			summaries = append(summaries, cached)
+			continue
+		}
+
+		summary, err := summarizeFileChange(ctx, provider, fc, budget)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize %s: %w", fc.Path, err)
+		}
+		summaries = append(summaries, *summary)
+		cache[key] = *summary
+	}
+
+	if err := saveSummaryCache(cache); err != nil {
+		return "", err
+	}
+
+	return renderSummaries(statusShort, summaries), nil
+}
+
+// summarizeFileChange asks the LLM for a short summary of one file's
+// change, reusing the regular commit-message Generate call in freeform mode
+// (its Title/Description make a perfectly good path/kind/symbols summary).
+// Files too large even after per-hunk chunking are summarized piece by
+// piece and the results concatenated.
+func summarizeFileChange(ctx context.Context, provider Provider, fc FileChange, budgetTokens int) (*FileSummary, error) {
+	pieces := chunkFileDiff(fc.Diff, budgetTokens)
+
+	var parts []string
+	for _, piece := range pieces {
+		msg, err := provider.Generate(ctx, piece, GenerateOptions{
+			Style:    StyleFreeform,
+			Language: "English",
+			ExtraInstruction: fmt.Sprintf(
+				"This is only a %s diff of a single file (%s). In the title, name the key symbols (functions/types) added or removed. Be terse.",
+				fc.ChangeKind, fc.Path,
+			),
+		})
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, strings.TrimSpace(msg.Title+": "+msg.Description))
+	}
+
+	return &FileSummary{
+		Path:       fc.Path,
+		ChangeKind: fc.ChangeKind,
+		Summary:    strings.Join(parts, " "),
+	}, nil
+}
+
+// renderDiffWithStatus prepends `git status --short` to diff, preserving
+// the status context (renames/adds/deletes) that splitDiffByFile strips
+// from an untouched diff.
+func renderDiffWithStatus(statusShort, diff string) string {
+	return "Git Status (staged files):\n" + statusShort + "\nGit Diff (staged changes):\n" + diff
+}
+
+// renderSummaries assembles the condensed text fed into the final
+// commit-message generation call when the original diff was too large.
+func renderSummaries(statusShort string, summaries []FileSummary) string {
+	var b strings.Builder
+	b.WriteString("Git Status (staged files):\n")
+	b.WriteString(statusShort)
+	b.WriteString("\nPer-file summaries (diff was too large to include in full):\n")
+	for _, s := range summaries {
+		fmt.Fprintf(&b, "- [%s] %s: %s\n", s.ChangeKind, s.Path, s.Summary)
+	}
+	return b.String()
+}
+
+// summaryCacheKey identifies a FileChange by the blob SHA of its current
+// on-disk content (falling back to the pre-image blob SHA for deletions),
+// so unchanged files reuse their cached summary across runs.
+func summaryCacheKey(fc FileChange) string {
+	if fc.ChangeKind != "deleted" {
+		if out, err := exec.Command("git", "hash-object", "--", fc.Path).Output(); err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	}
+	// Deleted files (or a hash-object failure) fall back to a content hash
+	// of the diff section itself, which is just as stable a cache key.
+	sum := sha256.Sum256([]byte(fc.Diff))
+	return hex.EncodeToString(sum[:])
+}
+
+func summaryCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve cache dir: %w", err)
+	}
+	return filepath.Join(dir, "auto_commit", "summaries.json"), nil
+}
+
+func loadSummaryCache() (map[string]FileSummary, error) {
+	path, err := summaryCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]FileSummary{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read summary cache: %w", err)
+	}
+
+	cache := map[string]FileSummary{}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return map[string]FileSummary{}, nil
+	}
+	return cache, nil
+}
+
+func saveSummaryCache(cache map[string]FileSummary) error {
+	path, err := summaryCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary cache: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}